@@ -1,40 +1,103 @@
 package api
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"fastapp/internal/feed"
+	fastappgrpc "fastapp/internal/grpc"
+	"fastapp/internal/grpc/pb"
 	"fastapp/internal/metrics"
 	"fastapp/internal/storage"
 	"fastapp/internal/updater"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/IBM/sarama"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gofiber/adaptor/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	feedService *feed.Service
-	fiberApp    *fiber.App
+	feedService   *feed.Service
+	metrics       *metrics.Metrics
+	fiberApp      *fiber.App
+	grpcServer    *grpc.Server
+	grpcAddr      string
+	logger        *slog.Logger
+	subscriberSeq atomic.Uint64
 }
 
 func NewApp() *App {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
-	feedStorage := storage.NewStorage()
+	feedStorage := newFeedStorage(logger)
 	fixedFeedStorage := storage.NewGoldenFixedStorage()
-	metrics := metrics.NewDummyMetrics()
-	feedService := feed.NewService(feedStorage, fixedFeedStorage, metrics, logger)
+	appMetrics := metrics.NewMetrics(feedStorage)
+	feedService := feed.NewService(feedStorage, fixedFeedStorage, appMetrics, logger)
+
+	grpcAddr := os.Getenv("FASTAPP_GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = ":9090"
+	}
+	grpcServer := grpc.NewServer(grpc.ForceServerCodec(pb.Codec{}))
+	pb.RegisterFeedServiceServer(grpcServer, fastappgrpc.NewServer(feedService, logger))
 
 	app := &App{
 		feedService: feedService,
+		metrics:     appMetrics,
 		fiberApp:    fiber.New(fiber.Config{}),
+		grpcServer:  grpcServer,
+		grpcAddr:    grpcAddr,
+		logger:      logger,
 	}
 	app.fiberApp.Get("/feed/:userId", app.feedHandler)
-	updater.UpdateFeed(context.Background(), feedStorage, 5_000_000, 10_000_000)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(appMetrics)
+	app.fiberApp.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	app.fiberApp.Get("/admin/metrics", app.metricsStreamHandler)
+
+	go appMetrics.Run(context.Background())
+
+	// FASTAPP_KAFKA_UPDATER is a feature flag: until the Kafka pipeline is
+	// rolled out everywhere, local dev and unconfigured environments keep
+	// using the random updater.
+	if os.Getenv("FASTAPP_KAFKA_UPDATER") == "true" {
+		kafkaCfg := updater.Config{
+			Brokers:       strings.Split(os.Getenv("FASTAPP_KAFKA_BROKERS"), ","),
+			Topic:         os.Getenv("FASTAPP_KAFKA_TOPIC"),
+			GroupID:       os.Getenv("FASTAPP_KAFKA_GROUP_ID"),
+			InitialOffset: sarama.OffsetNewest,
+			DLQTopic:      os.Getenv("FASTAPP_KAFKA_DLQ_TOPIC"),
+		}
+		consumer, err := updater.NewKafkaConsumer(kafkaCfg, feedStorage, appMetrics, logger)
+		if err != nil {
+			logger.Error("failed to start kafka updater, falling back to random updater", "error", err)
+			updater.UpdateFeed(context.Background(), feedStorage, 5_000_000, 10_000_000)
+		} else {
+			go func() {
+				if err := consumer.Run(context.Background()); err != nil {
+					logger.Error("kafka updater stopped", "error", err)
+				}
+			}()
+		}
+	} else {
+		updater.UpdateFeed(context.Background(), feedStorage, 5_000_000, 10_000_000)
+	}
 	go func() {
 		for {
 			time.Sleep(5 * time.Second)
@@ -45,6 +108,38 @@ func NewApp() *App {
 	return app
 }
 
+// newFeedStorage selects the FeedStore driver from FASTAPP_STORAGE_DRIVER:
+// "memory" (default, single box, lost on restart), "redis", "disk", or "s3".
+// Swapping the driver here is the only change needed; feed.Service only
+// depends on the narrow feedStorage interface it declares itself.
+func newFeedStorage(logger *slog.Logger) storage.FeedStore {
+	switch os.Getenv("FASTAPP_STORAGE_DRIVER") {
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: os.Getenv("FASTAPP_REDIS_ADDR")})
+		return storage.NewRedisStore(client)
+	case "disk":
+		return storage.NewObjectFeedStore(storage.NewDiskStorage(os.Getenv("FASTAPP_DISK_STORAGE_ROOT")))
+	case "s3":
+		awsCfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			logger.Error("failed to load aws config, falling back to in-memory storage", "error", err)
+			return storage.NewStorage(cacheMaxBytesFromEnv())
+		}
+		client := s3.NewFromConfig(awsCfg)
+		return storage.NewObjectFeedStore(storage.NewS3Storage(client, os.Getenv("FASTAPP_S3_BUCKET")))
+	default:
+		return storage.NewStorage(cacheMaxBytesFromEnv())
+	}
+}
+
+// cacheMaxBytesFromEnv reads FASTAPP_STORAGE_MAX_BYTES for the in-memory
+// cache's memory budget; storage.NewStorage falls back to its own default
+// when given 0.
+func cacheMaxBytesFromEnv() int64 {
+	maxBytes, _ := strconv.ParseInt(os.Getenv("FASTAPP_STORAGE_MAX_BYTES"), 10, 64)
+	return maxBytes
+}
+
 func (a *App) feedHandler(ctx *fiber.Ctx) error {
 	// Get userId from path params
 	userId, err := ctx.ParamsInt("userId")
@@ -79,6 +174,75 @@ func (a *App) feedHandler(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).SendString(sb.String())
 }
 
+// metricsStreamHandler streams newline-delimited JSON metrics.Snapshot
+// samples over a single long-lived HTTP response, modelled on MinIO's
+// streaming metrics handler. Query params: "interval" (seconds, min 1) sets
+// the sample rate, "n" (default unbounded) caps how many samples are sent
+// before the response closes.
+func (a *App) metricsStreamHandler(ctx *fiber.Ctx) error {
+	interval := time.Duration(ctx.QueryInt("interval", 1)) * time.Second
+	if interval < time.Second {
+		interval = time.Second
+	}
+	maxSamples := ctx.QueryInt("n", 0)
+
+	subscriberId := fmt.Sprintf("admin-metrics-%d", a.subscriberSeq.Add(1))
+	samples := a.metrics.Subscribe(subscriberId)
+
+	// The collector publishes at a fixed 1s base rate; forward every Nth
+	// sample so this subscriber matches the interval it asked for.
+	everyNth := int(interval / time.Second)
+
+	ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	reqCtx := ctx.Context()
+	reqCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The writer keeps running after the handler returns, so the
+		// subscription has to outlive the handler and end with the stream.
+		defer a.metrics.Unsubscribe(subscriberId)
+		received := 0
+		sent := 0
+		for {
+			select {
+			case <-reqCtx.Done():
+				return
+			case snap, ok := <-samples:
+				if !ok {
+					return
+				}
+				received++
+				if received%everyNth != 0 {
+					continue
+				}
+
+				line, err := json.Marshal(snap)
+				if err != nil {
+					return
+				}
+				line = append(line, '\n')
+				if _, err := w.Write(line); err != nil || w.Flush() != nil {
+					return
+				}
+
+				sent++
+				if maxSamples > 0 && sent >= maxSamples {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
 func (a *App) Run() error {
+	lis, err := net.Listen("tcp", a.grpcAddr)
+	if err != nil {
+		return fmt.Errorf("listen on grpc address %s: %w", a.grpcAddr, err)
+	}
+	go func() {
+		if err := a.grpcServer.Serve(lis); err != nil {
+			a.logger.Error("grpc server stopped", "error", err)
+		}
+	}()
+
 	return a.fiberApp.Listen(":8080")
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 )
 
 type Service struct {
@@ -34,6 +35,33 @@ type FeedRequest struct {
 }
 
 func (f *Service) RetrievFeed(ctx context.Context, r FeedRequest) ([]uint32, error) {
+	page, err := f.RetrievFeedPage(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// FeedPage is one page returned by RetrievFeedPage. It separates the
+// personalized items from the random fallback so a caller that requests
+// repeated pages for the same user (gRPC's WatchFeed) can tell when the
+// personalized feed has run dry instead of treating every page alike.
+type FeedPage struct {
+	Items             []uint32
+	PersonalizedCount int
+}
+
+// RetrievFeedPage is RetrievFeed's underlying implementation, kept separate
+// so callers that need the personalized/random split don't have to
+// re-derive it (PersonalizedCount is always 0 or the exact count returned
+// by feedStorage, so Items[:PersonalizedCount] is personalized and the rest
+// is random fill).
+func (f *Service) RetrievFeedPage(ctx context.Context, r FeedRequest) (FeedPage, error) {
+	start := time.Now()
+	defer func() {
+		f.errRecorder.RecordFeedLatency(ctx, time.Since(start))
+	}()
+
 	// Set default size if not specified
 	if r.Size == 0 {
 		r.Size = defailtNextFeedSize
@@ -41,11 +69,12 @@ func (f *Service) RetrievFeed(ctx context.Context, r FeedRequest) ([]uint32, err
 
 	var randomFeedSize uint8
 	// Get personalized feed for user
-	persFeed, err := f.feedStorage.NextFeed(ctx, r.UserId, r.Size)
+	persFeed, err := f.feedStorage.GetNextFeed(ctx, r.UserId, r.Size)
 	if err != nil {
 		f.errRecorder.RecordFeedError(ctx, r.UserId, err)
 	}
-	randomFeedSize = r.Size - uint8(len(persFeed))
+	personalizedCount := len(persFeed)
+	randomFeedSize = r.Size - uint8(personalizedCount)
 
 	// Fill remaining items with random feed
 	if randomFeedSize > 0 {
@@ -62,15 +91,16 @@ func (f *Service) RetrievFeed(ctx context.Context, r FeedRequest) ([]uint32, err
 			"persFeedSize", len(persFeed),
 			"requestedSize", r.Size)
 		if len(persFeed) == 0 {
-			return nil, errors.New("no feed items")
+			return FeedPage{}, errors.New("no feed items")
 		}
 	}
 
-	return persFeed, nil
+	f.errRecorder.RecordFeedServed(ctx, r.UserId, personalizedCount, len(persFeed)-personalizedCount)
+	return FeedPage{Items: persFeed, PersonalizedCount: personalizedCount}, nil
 }
 
 type feedStorage interface {
-	NextFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error)
+	GetNextFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error)
 }
 
 type randomFeedStorage interface {
@@ -79,4 +109,6 @@ type randomFeedStorage interface {
 
 type errRecorder interface {
 	RecordFeedError(ctx context.Context, userId uint32, err error)
+	RecordFeedLatency(ctx context.Context, latency time.Duration)
+	RecordFeedServed(ctx context.Context, userId uint32, personalizedCount, randomCount int)
 }
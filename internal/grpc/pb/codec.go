@@ -0,0 +1,33 @@
+package pb
+
+import "fmt"
+
+type wireMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+// Codec implements google.golang.org/grpc/encoding.Codec on top of the
+// hand-written wire methods in feed.go and wire.go, since the messages here aren't
+// protoc-generated. Servers and clients both need to set it explicitly
+// (grpc.ForceServerCodec / grpc.ForceCodec) rather than relying on the
+// default "proto" content-subtype.
+type Codec struct{}
+
+func (Codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("pb: %T does not implement wireMessage", v)
+	}
+	return m.Marshal()
+}
+
+func (Codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("pb: %T does not implement wireMessage", v)
+	}
+	return m.Unmarshal(data)
+}
+
+func (Codec) Name() string { return "fastapp-proto" }
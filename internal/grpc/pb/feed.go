@@ -0,0 +1,99 @@
+// Package pb holds the wire types for FeedService, defined in
+// ../proto/feed.proto. This repo has no protoc toolchain wired into CI yet,
+// so rather than check in protoc-gen-go output that nothing regenerates,
+// these types implement the same proto3 wire format by hand: varint fields
+// and a packed-varint encoding for repeated uint32, which is what protoc
+// would produce for `repeated uint32 foo = N [packed = true];` anyway.
+package pb
+
+import "fmt"
+
+type FeedRequest struct {
+	UserId uint32
+	Size   uint32
+}
+
+type FeedResponse struct {
+	ItemIds []uint32
+}
+
+type FeedChunk struct {
+	ItemIds []uint32
+	Offset  uint32
+}
+
+func (m *FeedRequest) Marshal() ([]byte, error) {
+	var buf []byte
+	buf = appendVarintField(buf, 1, uint64(m.UserId))
+	buf = appendVarintField(buf, 2, uint64(m.Size))
+	return buf, nil
+}
+
+func (m *FeedRequest) Unmarshal(data []byte) error {
+	*m = FeedRequest{}
+	return eachField(data, func(fieldNum, wireType int, data []byte) ([]byte, error) {
+		v, rest, err := expectVarint(fieldNum, wireType, data)
+		if err != nil {
+			return nil, err
+		}
+		switch fieldNum {
+		case 1:
+			m.UserId = uint32(v)
+		case 2:
+			m.Size = uint32(v)
+		}
+		return rest, nil
+	})
+}
+
+func (m *FeedResponse) Marshal() ([]byte, error) {
+	var buf []byte
+	buf = appendPackedVarintField(buf, 1, m.ItemIds)
+	return buf, nil
+}
+
+func (m *FeedResponse) Unmarshal(data []byte) error {
+	*m = FeedResponse{}
+	return eachField(data, func(fieldNum, wireType int, data []byte) ([]byte, error) {
+		if fieldNum != 1 {
+			return nil, fmt.Errorf("pb: FeedResponse: unknown field %d", fieldNum)
+		}
+		items, rest, err := expectPackedVarint(wireType, data)
+		if err != nil {
+			return nil, err
+		}
+		m.ItemIds = items
+		return rest, nil
+	})
+}
+
+func (m *FeedChunk) Marshal() ([]byte, error) {
+	var buf []byte
+	buf = appendPackedVarintField(buf, 1, m.ItemIds)
+	buf = appendVarintField(buf, 2, uint64(m.Offset))
+	return buf, nil
+}
+
+func (m *FeedChunk) Unmarshal(data []byte) error {
+	*m = FeedChunk{}
+	return eachField(data, func(fieldNum, wireType int, data []byte) ([]byte, error) {
+		switch fieldNum {
+		case 1:
+			items, rest, err := expectPackedVarint(wireType, data)
+			if err != nil {
+				return nil, err
+			}
+			m.ItemIds = items
+			return rest, nil
+		case 2:
+			v, rest, err := expectVarint(fieldNum, wireType, data)
+			if err != nil {
+				return nil, err
+			}
+			m.Offset = uint32(v)
+			return rest, nil
+		default:
+			return nil, fmt.Errorf("pb: FeedChunk: unknown field %d", fieldNum)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package pb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// This file is the part protoc-gen-go-grpc would normally generate from
+// the `service FeedService` block in feed.proto: the server interface, the
+// stream type for WatchFeed, and the grpc.ServiceDesc that wires method
+// names to handlers.
+
+const (
+	FeedService_GetFeed_FullMethodName   = "/fastapp.feed.v1.FeedService/GetFeed"
+	FeedService_WatchFeed_FullMethodName = "/fastapp.feed.v1.FeedService/WatchFeed"
+	FeedService_ServiceName              = "fastapp.feed.v1.FeedService"
+)
+
+type FeedServiceServer interface {
+	GetFeed(context.Context, *FeedRequest) (*FeedResponse, error)
+	WatchFeed(*FeedRequest, FeedService_WatchFeedServer) error
+}
+
+type FeedService_WatchFeedServer interface {
+	Send(*FeedChunk) error
+	grpc.ServerStream
+}
+
+type feedServiceWatchFeedServer struct {
+	grpc.ServerStream
+}
+
+func (x *feedServiceWatchFeedServer) Send(chunk *FeedChunk) error {
+	return x.ServerStream.SendMsg(chunk)
+}
+
+func RegisterFeedServiceServer(s grpc.ServiceRegistrar, srv FeedServiceServer) {
+	s.RegisterService(&FeedService_ServiceDesc, srv)
+}
+
+func _FeedService_GetFeed_Handler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(FeedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeedServiceServer).GetFeed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: FeedService_GetFeed_FullMethodName}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(FeedServiceServer).GetFeed(ctx, req.(*FeedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FeedService_WatchFeed_Handler(srv any, stream grpc.ServerStream) error {
+	m := new(FeedRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FeedServiceServer).WatchFeed(m, &feedServiceWatchFeedServer{stream})
+}
+
+var FeedService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: FeedService_ServiceName,
+	HandlerType: (*FeedServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetFeed",
+			Handler:    _FeedService_GetFeed_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchFeed",
+			Handler:       _FeedService_WatchFeed_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "feed.proto",
+}
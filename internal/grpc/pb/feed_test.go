@@ -0,0 +1,92 @@
+package pb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeedRequestRoundTrip(t *testing.T) {
+	cases := []FeedRequest{
+		{},
+		{UserId: 42, Size: 10},
+		{UserId: 0, Size: 10},
+	}
+	for _, want := range cases {
+		data, err := want.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal(%+v): %v", want, err)
+		}
+		var got FeedRequest
+		if err := got.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal(%x): %v", data, err)
+		}
+		if got != want {
+			t.Errorf("round trip %+v: got %+v", want, got)
+		}
+	}
+}
+
+func TestFeedResponseRoundTrip(t *testing.T) {
+	cases := [][]uint32{
+		nil,
+		{1},
+		{1, 2, 3, 300, 1 << 20},
+	}
+	for _, want := range cases {
+		msg := FeedResponse{ItemIds: want}
+		data, err := msg.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", want, err)
+		}
+		var got FeedResponse
+		if err := got.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal(%x): %v", data, err)
+		}
+		if !reflect.DeepEqual(got.ItemIds, want) {
+			t.Errorf("round trip %v: got %v", want, got.ItemIds)
+		}
+	}
+}
+
+func TestFeedChunkRoundTrip(t *testing.T) {
+	want := FeedChunk{ItemIds: []uint32{7, 8, 9}, Offset: 21}
+	data, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal(%+v): %v", want, err)
+	}
+	var got FeedChunk
+	if err := got.Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal(%x): %v", data, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip %+v: got %+v", want, got)
+	}
+}
+
+func TestFeedChunkUnmarshalRejectsUnknownField(t *testing.T) {
+	data := appendVarintField(nil, 3, 1)
+	var got FeedChunk
+	if err := got.Unmarshal(data); err == nil {
+		t.Fatalf("Unmarshal of unknown field 3: want error, got nil")
+	}
+}
+
+func TestCodecRoundTripsThroughWireMessage(t *testing.T) {
+	var codec Codec
+	want := &FeedRequest{UserId: 5, Size: 3}
+	data, err := codec.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got := &FeedRequest{}
+	if err := codec.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if *got != *want {
+		t.Errorf("round trip via Codec: got %+v, want %+v", got, want)
+	}
+
+	if _, err := codec.Marshal(struct{}{}); err == nil {
+		t.Errorf("Marshal of non-wireMessage: want error, got nil")
+	}
+}
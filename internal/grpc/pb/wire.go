@@ -0,0 +1,109 @@
+package pb
+
+import "fmt"
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func appendTag(buf []byte, fieldNum, wireType int) []byte {
+	return appendVarint(buf, uint64(fieldNum)<<3|uint64(wireType))
+}
+
+// appendVarintField omits the field entirely when v is the proto3 zero
+// value, matching protoc-gen-go's encoding of scalar fields.
+func appendVarintField(buf []byte, fieldNum int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = appendTag(buf, fieldNum, wireVarint)
+	return appendVarint(buf, v)
+}
+
+func appendPackedVarintField(buf []byte, fieldNum int, values []uint32) []byte {
+	if len(values) == 0 {
+		return buf
+	}
+	var packed []byte
+	for _, v := range values {
+		packed = appendVarint(packed, uint64(v))
+	}
+	buf = appendTag(buf, fieldNum, wireBytes)
+	buf = appendVarint(buf, uint64(len(packed)))
+	return append(buf, packed...)
+}
+
+func readVarint(data []byte) (v uint64, rest []byte, err error) {
+	var shift uint
+	for i, b := range data {
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v, data[i+1:], nil
+		}
+		shift += 7
+		if shift >= 64 {
+			return 0, nil, fmt.Errorf("pb: varint overflow")
+		}
+	}
+	return 0, nil, fmt.Errorf("pb: truncated varint")
+}
+
+// eachField walks data's top-level tag/value pairs, handing each one to fn
+// along with the bytes that follow the tag; fn returns the bytes remaining
+// after it consumes its field's value.
+func eachField(data []byte, fn func(fieldNum, wireType int, data []byte) ([]byte, error)) error {
+	for len(data) > 0 {
+		tag, rest, err := readVarint(data)
+		if err != nil {
+			return err
+		}
+		fieldNum, wireType := int(tag>>3), int(tag&0x7)
+		rest, err = fn(fieldNum, wireType, rest)
+		if err != nil {
+			return err
+		}
+		data = rest
+	}
+	return nil
+}
+
+func expectVarint(fieldNum, wireType int, data []byte) (uint64, []byte, error) {
+	if wireType != wireVarint {
+		return 0, nil, fmt.Errorf("pb: field %d: expected varint, got wire type %d", fieldNum, wireType)
+	}
+	return readVarint(data)
+}
+
+func expectPackedVarint(wireType int, data []byte) ([]uint32, []byte, error) {
+	if wireType != wireBytes {
+		return nil, nil, fmt.Errorf("pb: expected length-delimited packed field, got wire type %d", wireType)
+	}
+	length, rest, err := readVarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint64(len(rest)) < length {
+		return nil, nil, fmt.Errorf("pb: truncated packed field")
+	}
+	packed, rest := rest[:length], rest[length:]
+
+	var values []uint32
+	for len(packed) > 0 {
+		v, next, err := readVarint(packed)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, uint32(v))
+		packed = next
+	}
+	return values, rest, nil
+}
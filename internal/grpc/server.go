@@ -0,0 +1,82 @@
+// Package grpc adapts feed.Service to the FeedService gRPC service defined
+// in proto/feed.proto, so api.App can serve it on a second port alongside
+// the existing Fiber HTTP handler.
+package grpc
+
+import (
+	"context"
+	"fastapp/internal/feed"
+	"fastapp/internal/grpc/pb"
+	"log/slog"
+)
+
+type Server struct {
+	feedService *feed.Service
+	logger      *slog.Logger
+}
+
+func NewServer(feedService *feed.Service, logger *slog.Logger) *Server {
+	return &Server{feedService: feedService, logger: logger}
+}
+
+func (s *Server) GetFeed(ctx context.Context, req *pb.FeedRequest) (*pb.FeedResponse, error) {
+	items, err := s.feedService.RetrievFeed(ctx, feed.FeedRequest{
+		UserId: req.UserId,
+		Size:   uint8(req.Size),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.FeedResponse{ItemIds: items}, nil
+}
+
+// maxConsecutiveRandomPages bounds how many fully-random pages WatchFeed
+// will stream back to back once a user's personalized feed has run dry.
+// Without this, a long-lived watch on an exhausted user turns into an
+// unbounded firehose of resampled golden-fixture items with no offset to
+// ground it, each page counted as its own feed request in metrics.
+const maxConsecutiveRandomPages = 3
+
+// WatchFeed streams successive pages to the client without it having to
+// re-establish a connection per page. Each chunk goes through the same
+// feedService.RetrievFeedPage used by GetFeed (via RetrievFeed), so a cold
+// or exhausted user gets the same random-feed fallback and the same
+// latency/served metrics as a one-off request, instead of WatchFeed
+// silently starving them. The stream ends when the client disconnects,
+// RetrievFeedPage reports a genuine failure (surfaced as a stream error
+// rather than an empty, successful close), or the personalized feed has
+// clearly run dry (maxConsecutiveRandomPages fully-random pages in a row).
+func (s *Server) WatchFeed(req *pb.FeedRequest, stream pb.FeedService_WatchFeedServer) error {
+	ctx := stream.Context()
+	consecutiveRandomPages := 0
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		page, err := s.feedService.RetrievFeedPage(ctx, feed.FeedRequest{
+			UserId: req.UserId,
+			Size:   uint8(req.Size),
+		})
+		if err != nil {
+			s.logger.DebugContext(ctx, "watch feed stopped", "userId", req.UserId, "error", err)
+			return err
+		}
+		if len(page.Items) == 0 {
+			return nil
+		}
+
+		if page.PersonalizedCount == 0 {
+			consecutiveRandomPages++
+		} else {
+			consecutiveRandomPages = 0
+		}
+
+		if err := stream.Send(&pb.FeedChunk{ItemIds: page.Items}); err != nil {
+			return err
+		}
+		if consecutiveRandomPages >= maxConsecutiveRandomPages {
+			return nil
+		}
+	}
+}
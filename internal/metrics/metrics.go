@@ -0,0 +1,279 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fastapp/internal/storage"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latencyWindowSize bounds how many recent RetrievFeed latencies are kept
+// for the p50/p95/p99 estimate; old samples are overwritten in a ring.
+const latencyWindowSize = 1024
+
+// Metrics is the production metrics subsystem for the feed service: plain
+// atomic counters and a latency ring buffer, fed both to the /admin/metrics
+// streaming endpoint and to the /metrics Prometheus endpoint via the
+// prometheus.Collector implementation below, so both views read the same
+// counter set.
+type Metrics struct {
+	feedStorage storage.FeedStore
+
+	feedRequests atomic.Int64
+	personalized atomic.Int64
+	random       atomic.Int64
+	errsByClass  sync.Map // map[string]*atomic.Int64
+	consumerLag  sync.Map // map[string (topic+"/"+partition)]*atomic.Int64
+
+	latencies latencyWindow
+
+	subscribers sync.Map // map[string]chan Snapshot
+}
+
+func NewMetrics(feedStorage storage.FeedStore) *Metrics {
+	return &Metrics{feedStorage: feedStorage}
+}
+
+func (m *Metrics) RecordFeedError(ctx context.Context, userId uint32, err error) {
+	m.classCounter(errorClass(err)).Add(1)
+}
+
+func (m *Metrics) RecordFeedLatency(ctx context.Context, latency time.Duration) {
+	m.latencies.observe(latency)
+}
+
+func (m *Metrics) RecordFeedServed(ctx context.Context, userId uint32, personalizedCount, randomCount int) {
+	m.feedRequests.Add(1)
+	m.personalized.Add(int64(personalizedCount))
+	m.random.Add(int64(randomCount))
+}
+
+// RecordConsumerLag tracks how far behind the Kafka feed-update consumer is
+// for a given topic/partition, so operators can tell when the feed is stale.
+func (m *Metrics) RecordConsumerLag(ctx context.Context, topic string, partition int32, lag int64) {
+	key := fmt.Sprintf("%s/%d", topic, partition)
+	v, _ := m.consumerLag.LoadOrStore(key, new(atomic.Int64))
+	v.(*atomic.Int64).Store(lag)
+}
+
+func (m *Metrics) classCounter(class string) *atomic.Int64 {
+	v, _ := m.errsByClass.LoadOrStore(class, new(atomic.Int64))
+	return v.(*atomic.Int64)
+}
+
+// errorClass buckets a RetrievFeed error for the errsByClass counters.
+func errorClass(err error) string {
+	switch {
+	case err == nil:
+		return "none"
+	case errors.Is(err, storage.ErrColdUser):
+		return "cold_user"
+	default:
+		return "internal"
+	}
+}
+
+// cacheStatsSource is implemented by storage.Storage; not every FeedStore
+// driver has a hot-set cache to report on, so snapshot/Collect type-assert
+// for it instead of widening the FeedStore interface.
+type cacheStatsSource interface {
+	CacheStats() (hits, misses, evictions uint64)
+}
+
+// Snapshot is one sample streamed by /admin/metrics.
+type Snapshot struct {
+	Timestamp         time.Time        `json:"timestamp"`
+	RequestsPerSec    float64          `json:"requestsPerSec"`
+	P50LatencyMs      float64          `json:"p50LatencyMs"`
+	P95LatencyMs      float64          `json:"p95LatencyMs"`
+	P99LatencyMs      float64          `json:"p99LatencyMs"`
+	NumExceed         uint64           `json:"numExceed"`
+	PercentileExceed  float64          `json:"percentileExceed"`
+	ErrorsByClass     map[string]int64 `json:"errorsByClass"`
+	PersonalizedRatio float64          `json:"personalizedRatio"`
+	CacheHits         uint64           `json:"cacheHits"`
+	CacheMisses       uint64           `json:"cacheMisses"`
+	CacheEvictions    uint64           `json:"cacheEvictions"`
+}
+
+func (m *Metrics) snapshot(prevRequests *int64, elapsed time.Duration) Snapshot {
+	requests := m.feedRequests.Load()
+	rps := float64(requests-*prevRequests) / elapsed.Seconds()
+	*prevRequests = requests
+
+	p50, p95, p99 := m.latencies.percentiles()
+	numExceed, percentileExceed := m.feedStorage.GetPercentileExceed()
+
+	errsByClass := make(map[string]int64)
+	m.errsByClass.Range(func(key, value any) bool {
+		errsByClass[key.(string)] = value.(*atomic.Int64).Load()
+		return true
+	})
+
+	personalized := m.personalized.Load()
+	random := m.random.Load()
+	var personalizedRatio float64
+	if total := personalized + random; total > 0 {
+		personalizedRatio = float64(personalized) / float64(total)
+	}
+
+	snap := Snapshot{
+		Timestamp:         time.Now(),
+		RequestsPerSec:    rps,
+		P50LatencyMs:      float64(p50.Microseconds()) / 1000,
+		P95LatencyMs:      float64(p95.Microseconds()) / 1000,
+		P99LatencyMs:      float64(p99.Microseconds()) / 1000,
+		NumExceed:         numExceed,
+		PercentileExceed:  percentileExceed,
+		ErrorsByClass:     errsByClass,
+		PersonalizedRatio: personalizedRatio,
+	}
+	if cs, ok := m.feedStorage.(cacheStatsSource); ok {
+		snap.CacheHits, snap.CacheMisses, snap.CacheEvictions = cs.CacheStats()
+	}
+	return snap
+}
+
+// Run drives the single collector goroutine: every second it snapshots the
+// counter set and fans it out to every subscriber, so N HTTP subscribers to
+// /admin/metrics share one collection pass instead of each polling storage
+// independently.
+func (m *Metrics) Run(ctx context.Context) {
+	const tick = time.Second
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var prevRequests int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snap := m.snapshot(&prevRequests, tick)
+			m.subscribers.Range(func(key, value any) bool {
+				ch := value.(chan Snapshot)
+				select {
+				case ch <- snap:
+				default: // slow subscriber: drop rather than stall the collector
+				}
+				return true
+			})
+		}
+	}
+}
+
+// Subscribe registers subscriberId for the next snapshots published by Run.
+// Callers must Unsubscribe when done to stop receiving them.
+func (m *Metrics) Subscribe(subscriberId string) <-chan Snapshot {
+	ch := make(chan Snapshot, 1)
+	m.subscribers.Store(subscriberId, ch)
+	return ch
+}
+
+// Unsubscribe removes subscriberId so Run stops publishing to it. It never
+// closes the channel: Run's non-blocking send in the same channel is racing
+// this call from another goroutine, and closing here would let that send
+// land on a closed channel and panic. Leaving the channel unclosed is safe -
+// once it's out of m.subscribers, Run can't reach it again and it's
+// garbage-collected along with its one goroutine-free buffer slot.
+func (m *Metrics) Unsubscribe(subscriberId string) {
+	m.subscribers.Delete(subscriberId)
+}
+
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples [latencyWindowSize]time.Duration
+	next    int
+	filled  bool
+}
+
+func (w *latencyWindow) observe(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+	if w.next == 0 {
+		w.filled = true
+	}
+}
+
+func (w *latencyWindow) percentiles() (p50, p95, p99 time.Duration) {
+	w.mu.Lock()
+	n := w.next
+	if w.filled {
+		n = latencyWindowSize
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, w.samples[:n])
+	w.mu.Unlock()
+
+	if n == 0 {
+		return 0, 0, 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[percentileIndex(n, 0.50)], samples[percentileIndex(n, 0.95)], samples[percentileIndex(n, 0.99)]
+}
+
+func percentileIndex(n int, p float64) int {
+	idx := int(p * float64(n))
+	if idx >= n {
+		idx = n - 1
+	}
+	return idx
+}
+
+var (
+	feedRequestsDesc = prometheus.NewDesc("feed_requests_total", "Total number of RetrievFeed calls.", nil, nil)
+	personalizedDesc = prometheus.NewDesc("feed_personalized_items_total", "Total personalized items served.", nil, nil)
+	randomDesc       = prometheus.NewDesc("feed_random_items_total", "Total random fallback items served.", nil, nil)
+	feedLatencyDesc  = prometheus.NewDesc("feed_latency_seconds", "RetrievFeed latency quantile.", []string{"quantile"}, nil)
+	numExceedDesc    = prometheus.NewDesc("feed_num_exceed", "Number of requests that exhausted a user's precomputed feed.", nil, nil)
+	errorsDesc       = prometheus.NewDesc("feed_errors_total", "RetrievFeed errors by class.", []string{"class"}, nil)
+	consumerLagDesc  = prometheus.NewDesc("feed_updater_consumer_lag", "Kafka feed-updater consumer lag.", []string{"topic", "partition"}, nil)
+	cacheStatsDesc   = prometheus.NewDesc("feed_cache_events_total", "Hot-set cache hits/misses/evictions, for drivers that have one.", []string{"event"}, nil)
+)
+
+// Describe implements prometheus.Collector. errsByClass and consumerLag have
+// label values that only exist once observed, so nothing is sent here;
+// Collect below always emits the fixed-cardinality metrics and whatever
+// dynamic label values are currently populated.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(feedRequestsDesc, prometheus.CounterValue, float64(m.feedRequests.Load()))
+	ch <- prometheus.MustNewConstMetric(personalizedDesc, prometheus.CounterValue, float64(m.personalized.Load()))
+	ch <- prometheus.MustNewConstMetric(randomDesc, prometheus.CounterValue, float64(m.random.Load()))
+
+	p50, p95, p99 := m.latencies.percentiles()
+	ch <- prometheus.MustNewConstMetric(feedLatencyDesc, prometheus.GaugeValue, p50.Seconds(), "p50")
+	ch <- prometheus.MustNewConstMetric(feedLatencyDesc, prometheus.GaugeValue, p95.Seconds(), "p95")
+	ch <- prometheus.MustNewConstMetric(feedLatencyDesc, prometheus.GaugeValue, p99.Seconds(), "p99")
+
+	numExceed, _ := m.feedStorage.GetPercentileExceed()
+	ch <- prometheus.MustNewConstMetric(numExceedDesc, prometheus.GaugeValue, float64(numExceed))
+
+	m.errsByClass.Range(func(key, value any) bool {
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.CounterValue, float64(value.(*atomic.Int64).Load()), key.(string))
+		return true
+	})
+
+	m.consumerLag.Range(func(key, value any) bool {
+		topic, partition, _ := strings.Cut(key.(string), "/")
+		ch <- prometheus.MustNewConstMetric(consumerLagDesc, prometheus.GaugeValue, float64(value.(*atomic.Int64).Load()), topic, partition)
+		return true
+	})
+
+	if cs, ok := m.feedStorage.(cacheStatsSource); ok {
+		hits, misses, evictions := cs.CacheStats()
+		ch <- prometheus.MustNewConstMetric(cacheStatsDesc, prometheus.CounterValue, float64(hits), "hit")
+		ch <- prometheus.MustNewConstMetric(cacheStatsDesc, prometheus.CounterValue, float64(misses), "miss")
+		ch <- prometheus.MustNewConstMetric(cacheStatsDesc, prometheus.CounterValue, float64(evictions), "evict")
+	}
+}
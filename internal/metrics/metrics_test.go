@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"fastapp/internal/storage"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSubscribeUnsubscribeRace exercises concurrent Subscribe/Unsubscribe
+// against a running collector. Before e7c6d55, Unsubscribe closed the
+// subscriber channel while Run's ticker could still be sending to it,
+// which panics with "send on closed channel" - run with `go test -race`
+// to catch a regression of that bug.
+func TestSubscribeUnsubscribeRace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spans multiple 1s collector ticks; skipping in -short mode")
+	}
+
+	m := NewMetrics(storage.NewStorage(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	// Long enough to span at least two of Run's 1s ticks, so the collector
+	// is actually sending to subscribers while they're being added/removed.
+	deadline := time.Now().Add(2200 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("subscriber-%d", i)
+			for time.Now().Before(deadline) {
+				ch := m.Subscribe(id)
+				select {
+				case <-ch:
+				case <-time.After(time.Millisecond):
+				}
+				m.Unsubscribe(id)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
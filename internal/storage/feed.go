@@ -1,61 +1,186 @@
 package storage
 
 import (
+	"container/list"
 	"context"
+	"errors"
 	"fastapp/internal/feed"
-	"fmt"
 	"sync"
 	"sync/atomic"
 )
 
+// ErrColdUser is returned by GetNextFeed when userId isn't present in the
+// cache - never seen, or evicted to stay under the memory budget. It isn't
+// a failure: feed.Service treats it like any other feedStorage error and
+// falls through to the random feed, which makes Storage a hot-set
+// accelerator rather than the source of truth for personalized feeds.
+var ErrColdUser = errors.New("cold user: not present in feed cache")
+
+// entryBytes is the per-user footprint counted against MaxBytes. It only
+// accounts for the feed payload itself; map and list-node overhead is
+// ignored, same simplification the original sizing comment on UpdateFeed
+// made.
+const entryBytes = feed.TotalFeedSize * 4
+
+// DefaultMaxBytes caps the cache around the ~768MB the original sizing
+// comment on UpdateFeed estimated for 1M users, rounded up.
+const DefaultMaxBytes = 800 * 1024 * 1024
+
+type segment int
+
+const (
+	probationSegment segment = iota
+	protectedSegment
+)
+
+type entry struct {
+	userId  uint32
+	items   [feed.TotalFeedSize]uint32
+	offset  uint16
+	hits    int
+	segment segment
+	elem    *list.Element
+}
+
+// Storage is a segmented (2Q-style) in-memory cache for personalized
+// feeds, bounded by MaxBytes rather than growing without limit. Every
+// SetFeed'd user lands in a "probation" FIFO; a second GetNextFeed hit
+// promotes them into a larger "protected" segment, on the theory that a
+// user who comes back for more feed is worth keeping around longer.
+// Eviction always drains probation's tail before touching protected.
 type Storage struct {
-	feeds     map[uint32][feed.TotalFeedSize]uint32
-	offsets   sync.Map
+	mu        sync.Mutex
+	entries   map[uint32]*entry
+	probation *list.List
+	protected *list.List
+
+	maxBytes     int64
+	currentBytes int64
+
 	numExceed atomic.Uint64
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
 }
 
-func NewStorage() *Storage {
+// NewStorage builds an empty cache bounded at maxBytes of feed payload. A
+// non-positive maxBytes falls back to DefaultMaxBytes.
+func NewStorage(maxBytes int64) *Storage {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
 	return &Storage{
-		feeds: make(map[uint32][feed.TotalFeedSize]uint32),
+		entries:   make(map[uint32]*entry),
+		probation: list.New(),
+		protected: list.New(),
+		maxBytes:  maxBytes,
 	}
 }
 
 func (s *Storage) GetNextFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error) {
-	// Get current offset for user
-	offsetVal, _ := s.offsets.Load(userId)
-	var offset uint16
-	if offsetVal != nil {
-		offset = offsetVal.(uint16)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[userId]
+	if !ok {
+		s.misses.Add(1)
+		return nil, ErrColdUser
 	}
+	s.hits.Add(1)
+	s.touch(e)
 
 	// Return empty if user has seen all items
-	if int(offset) >= feed.TotalFeedSize {
+	if int(e.offset) >= feed.TotalFeedSize {
 		return nil, nil
 	}
 
 	// Calculate how many items to return, bounded by total feed size
-	lastItem := min(int(offset)+int(size), feed.TotalFeedSize)
+	lastItem := min(int(e.offset)+int(size), feed.TotalFeedSize)
 	if lastItem >= feed.TotalFeedSize {
 		s.numExceed.Add(1)
 	}
 
-	// Get user's feed array and slice the requested portion
-	feed, ok := s.feeds[userId]
-	if !ok {
-		return nil, fmt.Errorf("no feed found for user %d", userId)
-	}
-	items := feed[offset:lastItem]
+	// Copy out of the entry rather than slicing it directly: the entry
+	// stays live in the map/lists after we unlock, and a concurrent SetFeed
+	// would otherwise race with the caller reading this slice.
+	items := make([]uint32, lastItem-int(e.offset))
+	copy(items, e.items[e.offset:lastItem])
 
-	// Update user's offset
-	s.offsets.Store(userId, uint16(lastItem))
+	e.offset = uint16(lastItem)
 	return items, nil
 }
 
-func (s *Storage) SetFeed(ctx context.Context, userId uint32, items [feed.TotalFeedSize]uint32) {
-	s.feeds[userId] = items
-	s.offsets.Store(userId, uint16(0))
+// touch records an access against e, promoting it out of probation on its
+// second hit and refreshing its recency once it's in protected.
+func (s *Storage) touch(e *entry) {
+	e.hits++
+	if e.segment == protectedSegment {
+		s.protected.MoveToFront(e.elem)
+		return
+	}
+	if e.hits >= 2 {
+		s.probation.Remove(e.elem)
+		e.segment = protectedSegment
+		e.elem = s.protected.PushFront(e)
+	}
+}
+
+func (s *Storage) SetFeed(ctx context.Context, userId uint32, items [feed.TotalFeedSize]uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[userId]; ok {
+		e.items = items
+		e.offset = 0
+	} else {
+		e := &entry{userId: userId, items: items, segment: probationSegment}
+		e.elem = s.probation.PushFront(e)
+		s.entries[userId] = e
+		s.currentBytes += entryBytes
+	}
+
+	s.evictUntilUnderBudget()
+	return nil
+}
+
+// evictUntilUnderBudget pops entries until currentBytes is back under
+// maxBytes, always draining probation's tail (its oldest, least-proven
+// entries) before it starts on protected.
+func (s *Storage) evictUntilUnderBudget() {
+	for s.currentBytes > s.maxBytes {
+		elem := s.probation.Back()
+		from := s.probation
+		if elem == nil {
+			elem = s.protected.Back()
+			from = s.protected
+		}
+		if elem == nil {
+			return
+		}
+
+		from.Remove(elem)
+		e := elem.Value.(*entry)
+		delete(s.entries, e.userId)
+		s.currentBytes -= entryBytes
+		s.evictions.Add(1)
+	}
 }
 
 func (s *Storage) GetPercentileExceed() (uint64, float64) {
-	return s.numExceed.Load(), float64(s.numExceed.Load()) / float64(len(s.feeds))
+	s.mu.Lock()
+	numUsers := len(s.entries)
+	s.mu.Unlock()
+
+	numExceed := s.numExceed.Load()
+	if numUsers == 0 {
+		return numExceed, 0
+	}
+	return numExceed, float64(numExceed) / float64(numUsers)
+}
+
+// CacheStats reports hit/miss/eviction counters since startup, so
+// metrics.Metrics can surface them without Storage depending on the
+// metrics package.
+func (s *Storage) CacheStats() (hits, misses, evictions uint64) {
+	return s.hits.Load(), s.misses.Load(), s.evictions.Load()
 }
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fastapp/internal/feed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// ObjectStorage is a small adapter over a byte-addressed object store,
+// modelled on the codeberg.org/gruf/go-storage pattern. Any backend that
+// satisfies it - local disk, S3, or something else entirely - can be used
+// as the durable layer under ObjectFeedStore.
+type ObjectStorage interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Stat(ctx context.Context, key string) (bool, error)
+	Seek(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+}
+
+// DiskStorage stores each key as a file under a root directory. It's the
+// local counterpart to S3Storage, useful for single-box deployments and
+// integration tests that don't want a real S3 bucket.
+type DiskStorage struct {
+	root string
+}
+
+func NewDiskStorage(root string) *DiskStorage {
+	return &DiskStorage{root: root}
+}
+
+func (d *DiskStorage) path(key string) string {
+	return filepath.Join(d.root, key)
+}
+
+func (d *DiskStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (d *DiskStorage) Put(ctx context.Context, key string, value []byte) error {
+	if err := os.MkdirAll(filepath.Dir(d.path(key)), 0o755); err != nil {
+		return fmt.Errorf("mkdir for %s: %w", key, err)
+	}
+	if err := os.WriteFile(d.path(key), value, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", key, err)
+	}
+	return nil
+}
+
+func (d *DiskStorage) Stat(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(d.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *DiskStorage) Seek(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	f, err := os.Open(d.path(key))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", key, err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("seek %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// S3Storage stores each key as an object in a single S3 bucket.
+type S3Storage struct {
+	client *s3.Client
+	bucket string
+}
+
+func NewS3Storage(client *s3.Client, bucket string) *S3Storage {
+	return &S3Storage{client: client, bucket: bucket}
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", key, err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(value),
+	})
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err == nil {
+		return true, nil
+	}
+
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NotFound" {
+		return false, nil
+	}
+	return false, fmt.Errorf("head %s: %w", key, err)
+}
+
+func (s *S3Storage) Seek(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-", offset)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("seek %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+// ObjectFeedStore is a FeedStore backed by an ObjectStorage, so the same
+// code works against local disk or S3 depending on which adapter is wired
+// in api.NewApp. Read cursors aren't object-store material (no atomic
+// increment primitive), so they're kept in memory like the in-memory
+// Storage driver; losing them on restart just means users replay from the
+// start of their feed rather than losing the feed itself.
+//
+// offsets is a sync.Map, but it is only read and written under the user's
+// mutex from userLocks: GetNextFeed has to read the offset, compute the page bounds and
+// write the advanced offset back as one atomic step, or two concurrent
+// requests for the same user can read the same offset and both serve the
+// same page while the stored offset only advances once.
+type ObjectFeedStore struct {
+	backend   ObjectStorage
+	offsets   sync.Map
+	userLocks sync.Map // map[uint32]*sync.Mutex
+	numExceed atomic.Uint64
+	numUsers  atomic.Uint64
+}
+
+func NewObjectFeedStore(backend ObjectStorage) *ObjectFeedStore {
+	return &ObjectFeedStore{backend: backend}
+}
+
+// lockFor returns the per-user mutex guarding userId's offset, creating it
+// on first use.
+func (o *ObjectFeedStore) lockFor(userId uint32) *sync.Mutex {
+	mu, _ := o.userLocks.LoadOrStore(userId, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+func (o *ObjectFeedStore) GetNextFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error) {
+	mu := o.lockFor(userId)
+	mu.Lock()
+	defer mu.Unlock()
+
+	offsetVal, _ := o.offsets.Load(userId)
+	var offset uint16
+	if offsetVal != nil {
+		offset = offsetVal.(uint16)
+	}
+
+	if int(offset) >= feed.TotalFeedSize {
+		return nil, nil
+	}
+
+	exists, err := o.backend.Stat(ctx, objectKey(userId))
+	if err != nil {
+		return nil, fmt.Errorf("stat feed for user %d: %w", userId, err)
+	}
+	if !exists {
+		return nil, ErrColdUser
+	}
+
+	lastItem := min(int(offset)+int(size), feed.TotalFeedSize)
+	if lastItem >= feed.TotalFeedSize {
+		o.numExceed.Add(1)
+	}
+
+	r, err := o.backend.Seek(ctx, objectKey(userId), int64(offset)*4)
+	if err != nil {
+		return nil, fmt.Errorf("seek feed for user %d: %w", userId, err)
+	}
+	defer r.Close()
+
+	raw := make([]byte, (lastItem-int(offset))*4)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("read feed for user %d: %w", userId, err)
+	}
+
+	items := make([]uint32, len(raw)/4)
+	for i := range items {
+		items[i] = binary.LittleEndian.Uint32(raw[i*4 : i*4+4])
+	}
+
+	o.offsets.Store(userId, uint16(lastItem))
+	return items, nil
+}
+
+func (o *ObjectFeedStore) SetFeed(ctx context.Context, userId uint32, items [feed.TotalFeedSize]uint32) error {
+	mu := o.lockFor(userId)
+	mu.Lock()
+	defer mu.Unlock()
+
+	raw := make([]byte, feed.TotalFeedSize*4)
+	for i, item := range items {
+		binary.LittleEndian.PutUint32(raw[i*4:i*4+4], item)
+	}
+
+	existed, err := o.backend.Stat(ctx, objectKey(userId))
+	if err != nil {
+		return fmt.Errorf("stat feed for user %d: %w", userId, err)
+	}
+	if err := o.backend.Put(ctx, objectKey(userId), raw); err != nil {
+		return fmt.Errorf("put feed for user %d: %w", userId, err)
+	}
+	if !existed {
+		o.numUsers.Add(1)
+	}
+	o.offsets.Store(userId, uint16(0))
+	return nil
+}
+
+func (o *ObjectFeedStore) GetPercentileExceed() (uint64, float64) {
+	numExceed := o.numExceed.Load()
+	numUsers := o.numUsers.Load()
+	if numUsers == 0 {
+		return numExceed, 0
+	}
+	return numExceed, float64(numExceed) / float64(numUsers)
+}
+
+func objectKey(userId uint32) string {
+	return fmt.Sprintf("feeds/%d.bin", userId)
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"fastapp/internal/feed"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a FeedStore backed by Redis, so feeds survive restarts and
+// can be shared by multiple pod replicas. Each user's feed is stored as a
+// packed binary string under feedKeyPrefix, and the read cursor lives in a
+// separate offsetKeyPrefix key. GetNextFeed runs entirely inside a single
+// Lua script (getNextFeedScript) so the read-compute-advance sequence is
+// atomic on the Redis side; two pods serving the same user concurrently
+// can't both read the same offset and double-serve or skip a page.
+type RedisStore struct {
+	client *redis.Client
+}
+
+const (
+	feedKeyPrefix   = "feed:"
+	offsetKeyPrefix = "offset:"
+	usersSetKey     = "feed:users"
+	numExceedKey    = "feed:stats:numExceed"
+)
+
+// getNextFeedScript does in one atomic step what GetNextFeed used to do in
+// two separate pipelined round trips: check the feed exists, read the
+// current offset, compute the page bounds, fetch the bytes and advance the
+// offset. It returns a two-element array: a status (0 = ok, 1 = offset
+// already past the end, 2 = no feed set for this user) and the raw page
+// bytes (empty unless status is 0).
+var getNextFeedScript = redis.NewScript(`
+local feedKey = KEYS[1]
+local offsetKey = KEYS[2]
+local numExceedKey = KEYS[3]
+local size = tonumber(ARGV[1])
+local total = tonumber(ARGV[2])
+
+if redis.call('EXISTS', feedKey) == 0 then
+	return {2, ''}
+end
+
+local offset = tonumber(redis.call('GET', offsetKey) or '0')
+if offset >= total then
+	return {1, ''}
+end
+
+local lastItem = math.min(offset + size, total)
+if lastItem >= total then
+	redis.call('INCR', numExceedKey)
+end
+
+local data = redis.call('GETRANGE', feedKey, offset*4, lastItem*4-1)
+redis.call('INCRBY', offsetKey, lastItem-offset)
+return {0, data}
+`)
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (r *RedisStore) GetNextFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error) {
+	res, err := getNextFeedScript.Run(ctx, r.client, []string{feedKey(userId), offsetKey(userId), numExceedKey}, size, feed.TotalFeedSize).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fetch feed for user %d: %w", userId, err)
+	}
+
+	parts, ok := res.([]interface{})
+	if !ok || len(parts) != 2 {
+		return nil, fmt.Errorf("fetch feed for user %d: unexpected script result %v", userId, res)
+	}
+	status, _ := parts[0].(int64)
+	switch status {
+	case 2:
+		return nil, ErrColdUser
+	case 1:
+		return nil, nil
+	}
+
+	raw := []byte(parts[1].(string))
+	items := make([]uint32, len(raw)/4)
+	for i := range items {
+		items[i] = binary.LittleEndian.Uint32(raw[i*4 : i*4+4])
+	}
+	return items, nil
+}
+
+func (r *RedisStore) SetFeed(ctx context.Context, userId uint32, items [feed.TotalFeedSize]uint32) error {
+	raw := make([]byte, feed.TotalFeedSize*4)
+	for i, item := range items {
+		binary.LittleEndian.PutUint32(raw[i*4:i*4+4], item)
+	}
+
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, feedKey(userId), raw, 0)
+	pipe.Set(ctx, offsetKey(userId), 0, 0)
+	pipe.SAdd(ctx, usersSetKey, userId)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("set feed for user %d: %w", userId, err)
+	}
+	return nil
+}
+
+func (r *RedisStore) GetPercentileExceed() (uint64, float64) {
+	ctx := context.Background()
+	numExceed, err := r.client.Get(ctx, numExceedKey).Uint64()
+	if err != nil && err != redis.Nil {
+		return 0, 0
+	}
+	numUsers, err := r.client.SCard(ctx, usersSetKey).Result()
+	if err != nil || numUsers == 0 {
+		return numExceed, 0
+	}
+	return numExceed, float64(numExceed) / float64(numUsers)
+}
+
+func feedKey(userId uint32) string {
+	return feedKeyPrefix + fmt.Sprint(userId)
+}
+
+func offsetKey(userId uint32) string {
+	return offsetKeyPrefix + fmt.Sprint(userId)
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+	"fastapp/internal/feed"
+)
+
+// FeedStore is the storage driver contract for personalized feeds. It lets
+// feed.Service stay unchanged while the actual backing store is swapped
+// between the in-memory map (feed.go), Redis (redis.go), and object storage
+// (object.go) drivers.
+type FeedStore interface {
+	GetNextFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error)
+	SetFeed(ctx context.Context, userId uint32, items [feed.TotalFeedSize]uint32) error
+	GetPercentileExceed() (uint64, float64)
+}
@@ -9,7 +9,7 @@ import (
 
 // formula of data size: numOfUsers * feedSize * 4 bytes (uint32) / 1024 / 1024 (MB)
 // for 1kk users and feed of size 200 around 768mb data will be stored
-func UpdateFeed(ctx context.Context, feedStorage *storage.Storage, maxUserId uint32, maxVideoId uint32) {
+func UpdateFeed(ctx context.Context, feedStorage storage.FeedStore, maxUserId uint32, maxVideoId uint32) {
 	numUsers := rand.Intn(int(maxUserId)) + 1
 	for i := range numUsers {
 		var newFeed [feed.TotalFeedSize]uint32
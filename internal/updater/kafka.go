@@ -0,0 +1,222 @@
+package updater
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fastapp/internal/feed"
+	"fastapp/internal/storage"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Config holds everything needed to join the feed-update consumer group.
+type Config struct {
+	Brokers       []string
+	Topic         string
+	GroupID       string
+	InitialOffset int64 // sarama.OffsetNewest or sarama.OffsetOldest
+	DLQTopic      string
+}
+
+// lagRecorder is the narrow slice of the metrics interface the Kafka
+// consumer needs; it mirrors the way feed.Service declares its own
+// dependency interfaces instead of depending on the concrete metrics type.
+type lagRecorder interface {
+	RecordConsumerLag(ctx context.Context, topic string, partition int32, lag int64)
+}
+
+// messageEnvelope is the wire format carried by the feed-update topic:
+// a per-user recommendation vector plus a version. The version is decoded
+// but not compared: every decoded message's feed is written as it arrives.
+type messageEnvelope struct {
+	UserId  uint32
+	Items   [feed.TotalFeedSize]uint32
+	Version uint64
+}
+
+// KafkaConsumer reads pre-computed per-user feeds off a Kafka topic and
+// writes them into a storage.FeedStore, replacing the random in-process
+// generator for production deployments.
+type KafkaConsumer struct {
+	cfg         Config
+	feedStorage storage.FeedStore
+	group       sarama.ConsumerGroup
+	dlqProducer sarama.SyncProducer
+	metrics     lagRecorder
+	logger      *slog.Logger
+}
+
+// NewKafkaConsumer dials the configured brokers and joins cfg.GroupID.
+func NewKafkaConsumer(cfg Config, feedStorage storage.FeedStore, metrics lagRecorder, logger *slog.Logger) (*KafkaConsumer, error) {
+	saramaCfg := sarama.NewConfig()
+	saramaCfg.Consumer.Offsets.Initial = cfg.InitialOffset
+	saramaCfg.Consumer.Return.Errors = true
+	saramaCfg.Producer.Return.Successes = true
+
+	group, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, saramaCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create consumer group: %w", err)
+	}
+
+	dlqProducer, err := sarama.NewSyncProducer(cfg.Brokers, saramaCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create dlq producer: %w", err)
+	}
+
+	return &KafkaConsumer{
+		cfg:         cfg,
+		feedStorage: feedStorage,
+		group:       group,
+		dlqProducer: dlqProducer,
+		metrics:     metrics,
+		logger:      logger,
+	}, nil
+}
+
+// Run joins the consumer group and blocks until ctx is cancelled. Rebalances
+// are handled transparently by sarama, which calls Setup/ConsumeClaim again
+// on this same *KafkaConsumer with a new session for every generation.
+func (k *KafkaConsumer) Run(ctx context.Context) error {
+	go k.logErrors(ctx)
+
+	for {
+		if err := k.group.Consume(ctx, []string{k.cfg.Topic}, k); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) || ctx.Err() != nil {
+				return ctx.Err()
+			}
+			k.logger.ErrorContext(ctx, "consumer group session failed", "error", err)
+		}
+	}
+}
+
+func (k *KafkaConsumer) Close() error {
+	return errors.Join(k.group.Close(), k.dlqProducer.Close())
+}
+
+func (k *KafkaConsumer) logErrors(ctx context.Context) {
+	for err := range k.group.Errors() {
+		k.logger.ErrorContext(ctx, "consumer group error", "error", err)
+	}
+}
+
+// Setup and Cleanup satisfy sarama.ConsumerGroupHandler; nothing to do on
+// either side since SetFeed is idempotent per message.
+func (k *KafkaConsumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (k *KafkaConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// maxSetFeedAttempts bounds how many times in all ConsumeClaim tries a
+// SetFeed (the first try included) before giving up and routing the
+// message to the DLQ. Without a
+// cap, a message that fails deterministically (bad downstream state, a
+// decodable-but-rejected envelope) would be redelivered and re-fail forever,
+// since a failed SetFeed never advances the committed offset.
+const maxSetFeedAttempts = 3
+
+// setFeedRetryBackoff is the base delay between SetFeed retries, doubled on
+// each attempt.
+const setFeedRetryBackoff = 200 * time.Millisecond
+
+func (k *KafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for msg := range claim.Messages() {
+		envelope, err := decodeEnvelope(msg.Value)
+		if err != nil {
+			k.logger.ErrorContext(ctx, "failed to decode feed update, sending to dlq", "error", err, "offset", msg.Offset)
+			if dlqErr := k.sendToDLQ(msg); dlqErr != nil {
+				k.logger.ErrorContext(ctx, "failed to publish to dlq", "error", dlqErr)
+			}
+			session.MarkMessage(msg, "")
+			continue
+		}
+
+		if err := k.setFeedWithRetry(ctx, envelope); err != nil {
+			k.logger.ErrorContext(ctx, "failed to set feed after retries, sending to dlq", "error", err, "userId", envelope.UserId, "offset", msg.Offset)
+			if dlqErr := k.sendToDLQ(msg); dlqErr != nil {
+				k.logger.ErrorContext(ctx, "failed to publish to dlq", "error", dlqErr)
+			}
+			session.MarkMessage(msg, "")
+			continue
+		}
+		session.MarkMessage(msg, "")
+		session.Commit()
+
+		if k.metrics != nil {
+			lag := claim.HighWaterMarkOffset() - msg.Offset - 1
+			k.metrics.RecordConsumerLag(ctx, k.cfg.Topic, claim.Partition(), lag)
+		}
+	}
+	return nil
+}
+
+// setFeedWithRetry tries SetFeed up to maxSetFeedAttempts times in all,
+// with a doubling backoff between tries, so a transient downstream blip doesn't send a
+// message straight to the DLQ that would have succeeded a moment later.
+func (k *KafkaConsumer) setFeedWithRetry(ctx context.Context, envelope messageEnvelope) error {
+	var err error
+	backoff := setFeedRetryBackoff
+	for attempt := 1; attempt <= maxSetFeedAttempts; attempt++ {
+		if err = k.feedStorage.SetFeed(ctx, envelope.UserId, envelope.Items); err == nil {
+			return nil
+		}
+		k.logger.WarnContext(ctx, "set feed failed, retrying", "error", err, "userId", envelope.UserId, "attempt", attempt)
+		if attempt == maxSetFeedAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+func (k *KafkaConsumer) sendToDLQ(msg *sarama.ConsumerMessage) error {
+	if k.cfg.DLQTopic == "" {
+		return nil
+	}
+	_, _, err := k.dlqProducer.SendMessage(&sarama.ProducerMessage{
+		Topic: k.cfg.DLQTopic,
+		Key:   sarama.ByteEncoder(msg.Key),
+		Value: sarama.ByteEncoder(msg.Value),
+	})
+	return err
+}
+
+// decodeEnvelope parses the compact binary framing:
+//
+//	uint32 userId | uint32 itemCount | itemCount * uint32 items | uint64 version
+//
+// all fields little-endian. itemCount must equal feed.TotalFeedSize.
+func decodeEnvelope(data []byte) (messageEnvelope, error) {
+	const headerSize = 4 + 4
+	if len(data) < headerSize {
+		return messageEnvelope{}, fmt.Errorf("message too short: %d bytes", len(data))
+	}
+
+	var envelope messageEnvelope
+	envelope.UserId = binary.LittleEndian.Uint32(data[0:4])
+	itemCount := binary.LittleEndian.Uint32(data[4:8])
+	if itemCount != feed.TotalFeedSize {
+		return messageEnvelope{}, fmt.Errorf("expected %d items, got %d", feed.TotalFeedSize, itemCount)
+	}
+
+	wantLen := headerSize + int(itemCount)*4 + 8
+	if len(data) < wantLen {
+		return messageEnvelope{}, fmt.Errorf("message truncated: want %d bytes, got %d", wantLen, len(data))
+	}
+
+	off := headerSize
+	for i := range envelope.Items {
+		envelope.Items[i] = binary.LittleEndian.Uint32(data[off : off+4])
+		off += 4
+	}
+	envelope.Version = binary.LittleEndian.Uint64(data[off : off+8])
+
+	return envelope, nil
+}
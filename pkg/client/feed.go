@@ -0,0 +1,76 @@
+// Package client is a typed gRPC client for FeedService, for downstream
+// services in the same monorepo that want feed items without parsing the
+// ad-hoc JSON array the Fiber /feed/:userId handler returns.
+package client
+
+import (
+	"context"
+	"fastapp/internal/grpc/pb"
+
+	"google.golang.org/grpc"
+)
+
+type FeedClient struct {
+	conn *grpc.ClientConn
+}
+
+// Dial opens a gRPC connection to a fastapp instance's gRPC port. Callers
+// supply their own transport credentials, e.g. grpc.WithTransportCredentials.
+func Dial(target string, opts ...grpc.DialOption) (*FeedClient, error) {
+	opts = append(opts, grpc.WithDefaultCallOptions(grpc.ForceCodec(pb.Codec{})))
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &FeedClient{conn: conn}, nil
+}
+
+func (c *FeedClient) Close() error {
+	return c.conn.Close()
+}
+
+// GetFeed fetches one page of feed items for userId.
+func (c *FeedClient) GetFeed(ctx context.Context, userId uint32, size uint8) ([]uint32, error) {
+	req := &pb.FeedRequest{UserId: userId, Size: uint32(size)}
+	resp := new(pb.FeedResponse)
+	if err := c.conn.Invoke(ctx, pb.FeedService_GetFeed_FullMethodName, req, resp); err != nil {
+		return nil, err
+	}
+	return resp.ItemIds, nil
+}
+
+// WatchFeed streams successive pages for userId onto the returned channel,
+// which is closed when ctx is cancelled or the server ends the stream: on
+// a genuine backend failure, or once the user's personalized feed has run
+// dry and several pages in a row came back purely from the random fallback
+// (the server fills the rest of each page from the random feed the same
+// way GetFeed does, but won't do that forever).
+func (c *FeedClient) WatchFeed(ctx context.Context, userId uint32, size uint8) (<-chan []uint32, error) {
+	stream, err := c.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, pb.FeedService_WatchFeed_FullMethodName)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&pb.FeedRequest{UserId: userId, Size: uint32(size)}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan []uint32)
+	go func() {
+		defer close(chunks)
+		for {
+			chunk := new(pb.FeedChunk)
+			if err := stream.RecvMsg(chunk); err != nil {
+				return
+			}
+			select {
+			case chunks <- chunk.ItemIds:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}